@@ -19,9 +19,11 @@ func (c *cleanups) add(f cleanupFunc) {
 
 func (c *cleanups) do() (err error) {
 	c.once.Do(func() {
+		var merr *multierror.Error
 		for i := len(c.funcs) - 1; i >= 0; i-- {
-			err = multierror.Append(err, c.funcs[i]())
+			merr = multierror.Append(merr, c.funcs[i]())
 		}
+		err = merr.ErrorOrNil()
 	})
 	return err
 }
@@ -10,8 +10,9 @@ func TestCleanup0(t *testing.T) {
 	cleanups := &cleanups{}
 
 	called := []string{}
-	cleanups.do()
+	err := cleanups.do()
 
+	assert.Nil(t, err)
 	assert.Equal(t, []string{}, called)
 }
 
@@ -24,8 +25,9 @@ func TestCleanup1(t *testing.T) {
 		return nil
 	})
 
-	cleanups.do()
+	err := cleanups.do()
 
+	assert.Nil(t, err)
 	assert.Equal(t, []string{"call1"}, called)
 }
 
@@ -49,8 +51,9 @@ func TestCleanup3(t *testing.T) {
 		return nil
 	})
 
-	cleanups.do()
+	err := cleanups.do()
 
+	assert.Nil(t, err)
 	assert.Equal(t, []string{
 		"call3",
 		"call2",
@@ -24,9 +24,17 @@ func main() {
 	param := split.Param{}
 	flag.BoolVar(&param.Verbose, "verbose", false, "Verbose output")
 	flag.IntVar(&param.Split, "split", 8, "Number of files that splitted")
+	flag.Int64Var(&param.SplitBytes, "split-bytes", 0, "Roll over to a new output every this many uncompressed bytes, instead of round-robin across --split writers")
+	flag.Int64Var(&param.SplitLines, "split-lines", 0, "Roll over to a new output every this many lines, instead of round-robin across --split writers")
 	flag.StringVar(&param.Prefix, "prefix", "out-", "Path prefix of outputs")
-	flag.StringVar(&param.Compress, "compress", "none", "{gzip|none=without compression}")
-	flag.IntVar(&param.Parallelism, "parallelism", 4, "Maximum number of files which read parallely")
+	flag.StringVar(&param.Compress, "compress", "none", "{gzip|zstd|brotli|none=without compression}")
+	flag.IntVar(&param.CompressLevel, "compress-level", 0, "Compression level passed to the chosen codec, 0=codec default")
+	flag.IntVar(&param.Parallelism, "parallelism", 4, "(deprecated, use -parallel-read/-parallel-write) Maximum number of files which read parallely")
+	flag.IntVar(&param.ParallelRead, "parallel-read", 0, "Maximum number of files read concurrently, 0=use -parallelism")
+	flag.IntVar(&param.ParallelWrite, "parallel-write", 0, "Buffer size between the scan and write stages; does not change the number of output files (always -split), 0=use -parallelism")
+	flag.IntVar(&param.CompressionConcurrency, "compression-concurrency", 0, "Maximum number of concurrent compressing writes, independent of -parallel-write. 0=no gating. No-op with -split-bytes/-split-lines, which only ever run one writer")
+	flag.StringVar(&param.StateFile, "state-file", "", "Path to a checkpoint file that records split progress for -resume")
+	optResume := flag.Bool("resume", false, "Resume a previous run from -state-file instead of starting fresh")
 
 	flag.Usage = usage
 	flag.Parse()
@@ -49,12 +57,31 @@ func main() {
 		os.Exit(1)
 	}
 
+	if param.ParallelRead < 0 || param.ParallelWrite < 0 {
+		usage()
+		fmt.Fprintf(os.Stderr, "*** --parallel-read and --parallel-write must be >= 0")
+		os.Exit(1)
+	}
+
 	if param.Split <= 0 {
 		usage()
 		fmt.Fprintf(os.Stderr, "*** --split must be >= 1")
 		os.Exit(1)
 	}
 
+	if param.SplitBytes > 0 && param.SplitLines > 0 {
+		usage()
+		fmt.Fprintf(os.Stderr, "*** --split-bytes and --split-lines are mutually exclusive")
+		os.Exit(1)
+	}
+
+	if *optResume && param.StateFile == "" {
+		usage()
+		fmt.Fprintf(os.Stderr, "*** --resume requires --state-file")
+		os.Exit(1)
+	}
+	param.Resume = *optResume
+
 	err := split.NewSplitter().Do(context.Background(), files, param)
 	if err != nil {
 		log.Printf("*** Splitter.Do: %v", err)
@@ -0,0 +1,32 @@
+package split
+
+import "context"
+
+// compressionPool bounds how many compression-heavy writes run at once,
+// independent of how many output files are open. A nil pool (the
+// CompressionConcurrency == 0 case) runs every call inline.
+type compressionPool struct {
+	sem chan struct{}
+}
+
+func newCompressionPool(n int) *compressionPool {
+	if n <= 0 {
+		return nil
+	}
+	return &compressionPool{sem: make(chan struct{}, n)}
+}
+
+func (p *compressionPool) run(ctx context.Context, f func() error) error {
+	if p == nil {
+		return f()
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.sem }()
+
+	return f()
+}
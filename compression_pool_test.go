@@ -0,0 +1,66 @@
+package split
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressionPoolNilRunsInline(t *testing.T) {
+	pool := newCompressionPool(0)
+	assert.Nil(t, pool)
+
+	called := false
+	err := pool.run(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, called)
+}
+
+func TestCompressionPoolBoundsConcurrency(t *testing.T) {
+	pool := newCompressionPool(2)
+
+	var mu sync.Mutex
+	current, max := 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.run(context.Background(), func() error {
+				mu.Lock()
+				current++
+				if current > max {
+					max = current
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				current--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, max, 2)
+}
+
+func TestParamParallelFallback(t *testing.T) {
+	p := Param{Parallelism: 4}
+	assert.Equal(t, 4, p.parallelRead())
+	assert.Equal(t, 4, p.parallelWrite())
+
+	p = Param{Parallelism: 4, ParallelRead: 2, ParallelWrite: 8}
+	assert.Equal(t, 2, p.parallelRead())
+	assert.Equal(t, 8, p.parallelWrite())
+}
@@ -7,6 +7,8 @@ const (
 	CompressionUnknown CompressionType = iota
 	CompressionNone
 	CompressionGzip
+	CompressionZstd
+	CompressionBrotli
 )
 
 type compressionTypeInfo struct {
@@ -15,10 +17,14 @@ type compressionTypeInfo struct {
 }
 
 var compressionMap = map[string]compressionTypeInfo{
-	"":     {CompressionNone, ""},
-	"none": {CompressionNone, ""},
-	"gzip": {CompressionGzip, ".gz"},
-	"gz":   {CompressionGzip, ".gz"},
+	"":       {CompressionNone, ""},
+	"none":   {CompressionNone, ""},
+	"gzip":   {CompressionGzip, ".gz"},
+	"gz":     {CompressionGzip, ".gz"},
+	"zstd":   {CompressionZstd, ".zst"},
+	"zst":    {CompressionZstd, ".zst"},
+	"brotli": {CompressionBrotli, ".br"},
+	"br":     {CompressionBrotli, ".br"},
 }
 
 func getCompressionType(compression string) (CompressionType, string) {
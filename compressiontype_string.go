@@ -11,11 +11,13 @@ func _() {
 	_ = x[CompressionUnknown-0]
 	_ = x[CompressionNone-1]
 	_ = x[CompressionGzip-2]
+	_ = x[CompressionZstd-3]
+	_ = x[CompressionBrotli-4]
 }
 
-const _CompressionType_name = "CompressionUnknownCompressionNoneCompressionGzip"
+const _CompressionType_name = "CompressionUnknownCompressionNoneCompressionGzipCompressionZstdCompressionBrotli"
 
-var _CompressionType_index = [...]uint8{0, 18, 33, 48}
+var _CompressionType_index = [...]uint8{0, 18, 33, 48, 63, 80}
 
 func (i CompressionType) String() string {
 	if i < 0 || i >= CompressionType(len(_CompressionType_index)-1) {
@@ -2,61 +2,196 @@ package split
 
 import (
 	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path"
 	"strings"
 	"sync/atomic"
 
+	"github.com/andybalholm/brotli"
 	"github.com/dustin/go-humanize"
 	"github.com/hashicorp/go-multierror"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
 	"golang.org/x/sync/errgroup"
 )
 
 var nop = func() error { return nil }
 
-func decorateWriter(compression string, w io.Writer) (io.Writer, cleanupFunc, error) {
+// magic byte sequences used by decorateReader to sniff compression from
+// content rather than file extension. Brotli has no magic number, so it is
+// still detected by extension.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte{0x42, 0x5a, 0x68}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+func decorateWriter(compression string, level int, w io.Writer) (io.Writer, cleanupFunc, error) {
 	ct, _ := getCompressionType(compression)
 	switch ct {
 	case CompressionNone:
 		return w, nop, nil
 	case CompressionGzip:
-		gzw := gzip.NewWriter(w)
+		if level == 0 {
+			gzw := gzip.NewWriter(w)
+			return gzw, func() error { return gzw.Close() }, nil
+		}
+		gzw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gzip.NewWriterLevel: %w", err)
+		}
 		return gzw, func() error { return gzw.Close() }, nil
+	case CompressionZstd:
+		opts := []zstd.EOption{}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zw, err := zstd.NewWriter(w, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("zstd.NewWriter: %w", err)
+		}
+		return zw, func() error { return zw.Close() }, nil
+	case CompressionBrotli:
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		bw := brotli.NewWriterLevel(w, level)
+		return bw, func() error { return bw.Close() }, nil
 	default:
 		return nil, nil, fmt.Errorf("unknown compression type: %s", compression)
 	}
 }
 
 func decorateReader(fn string, r io.Reader) (io.ReadCloser, error) {
-	if strings.HasSuffix(fn, ".gz") {
-		if gzr, err := gzip.NewReader(r); err != nil {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(6)
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
 			return nil, err
-		} else {
-			return gzr, nil
 		}
-	} else if strings.HasSuffix(fn, ".bz2") {
-		return io.NopCloser(bzip2.NewReader(r)), nil
+		return gzr, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case strings.HasSuffix(fn, ".br"):
+		return io.NopCloser(brotli.NewReader(br)), nil
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &seekableReader{br: br, src: r, seeker: seeker}, nil
 	}
-	return io.NopCloser(r), nil
+	return io.NopCloser(br), nil
+}
+
+// seekableReader lets scan's resume logic Seek an uncompressed source
+// straight to a checkpointed offset instead of reading-and-discarding,
+// even though decorateReader wraps it in a bufio.Reader for magic-byte
+// sniffing. Seeking the underlying source invalidates whatever the
+// bufio.Reader had already buffered, so Seek resets it.
+type seekableReader struct {
+	br     *bufio.Reader
+	src    io.Reader
+	seeker io.Seeker
+}
+
+func (r *seekableReader) Read(p []byte) (int, error) { return r.br.Read(p) }
+func (r *seekableReader) Close() error               { return nil }
+
+func (r *seekableReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, fmt.Errorf("seekableReader: only io.SeekStart is supported")
+	}
+	n, err := r.seeker.Seek(offset, io.SeekStart)
+	if err != nil {
+		return n, err
+	}
+	r.br.Reset(r.src)
+	return n, nil
 }
 
 type service interface {
-	createWriter(fn string, compress string) (io.Writer, cleanupFunc, error)
+	createWriter(fn string, compress string, level int, resume bool) (io.Writer, cleanupFunc, error)
 	createReader(fn string) (io.ReadCloser, error)
 	mkdirAll(path string, perm os.FileMode) error
+	zipEntries(fn string) ([]string, error)
+	readState(fn string) (*splitState, error)
+	writeState(fn string, st *splitState) error
 }
 
 type Param struct {
-	Verbose     bool
-	Split       int
+	Verbose    bool
+	Split      int
+	SplitBytes int64
+	SplitLines int64
+
+	// Parallelism is deprecated: use ParallelRead and ParallelWrite instead,
+	// which let read and write concurrency be sized independently. When
+	// either of those is 0, Parallelism is used as its fallback.
 	Parallelism int
-	Prefix      string
-	Compress    string
+
+	// ParallelRead is the number of input files/entries read concurrently.
+	// 0 falls back to Parallelism.
+	ParallelRead int
+	// ParallelWrite sizes the buffer between the scan and write stages in
+	// the default round-robin (non-rolling) split mode. It does not change
+	// how many output files are produced: write always runs one persistent
+	// writer goroutine per Split shard. 0 falls back to Parallelism.
+	ParallelWrite int
+	// CompressionConcurrency bounds how many Write calls are compressing at
+	// once, independent of ParallelWrite. This decouples codec CPU usage
+	// from the number of output files, which otherwise each compress
+	// inline on their own writer goroutine. 0 preserves that inline
+	// behavior (no gating). Only applies to the default round-robin split
+	// mode: writeRolling has a single active writer, so there is never
+	// more than one compressing Write in flight to bound.
+	CompressionConcurrency int
+
+	Prefix        string
+	Compress      string
+	CompressLevel int
+
+	// StateFile, if set, checkpoints progress so Do can resume after being
+	// interrupted: per-input-file scan offsets and per-shard line counts.
+	// It is written atomically on every return from Do, including context
+	// cancellation from a SIGINT.
+	StateFile string
+	// Resume loads StateFile's existing checkpoint, if any, instead of
+	// starting from scratch. Ignored when StateFile is empty.
+	Resume bool
+}
+
+// rolling reports whether param selects sequential size/line-based rollover
+// instead of the default round-robin fan-out across Split writers.
+func (p Param) rolling() bool {
+	return p.SplitBytes > 0 || p.SplitLines > 0
+}
+
+func (p Param) parallelRead() int {
+	if p.ParallelRead > 0 {
+		return p.ParallelRead
+	}
+	return p.Parallelism
+}
+
+func (p Param) parallelWrite() int {
+	if p.ParallelWrite > 0 {
+		return p.ParallelWrite
+	}
+	return p.Parallelism
 }
 
 type Splitter struct {
@@ -65,13 +200,30 @@ type Splitter struct {
 }
 
 func NewSplitter() *Splitter {
+	return NewSplitterWithFs(afero.NewOsFs())
+}
+
+// NewSplitterWithFs returns a Splitter whose reads and writes go through fs
+// instead of the local filesystem, allowing callers to plug in an in-memory
+// filesystem (for tests) or a wrapper around remote storage such as S3/GCS.
+func NewSplitterWithFs(fs afero.Fs) *Splitter {
 	return &Splitter{
 		stderr: os.Stderr,
-		svc:    &serviceImpl{},
+		svc:    &serviceImpl{fs: fs},
 	}
 }
 
-type line string
+// line is one scanned record in flight from scan to a writer. offset and
+// last let the writer stage (write/writeRolling), not scan, checkpoint
+// resume progress: scan only knows a line has been read, not that it has
+// been durably written, and chLine is buffered, so recording offset at
+// send time can claim a line that a cancelled writer never got to.
+type line struct {
+	path   string
+	text   string
+	offset int64
+	last   bool
+}
 
 type readTarget struct {
 	path string
@@ -82,21 +234,61 @@ func (s *Splitter) Do(ctx context.Context, files []string, param Param) (retErr
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	chLine := make(chan line, param.Parallelism)
+	var state *splitState
+	if param.StateFile != "" {
+		if param.Resume {
+			st, err := s.svc.readState(param.StateFile)
+			if err != nil {
+				return fmt.Errorf("readState: %w", err)
+			}
+			state = st
+		}
+		if state == nil {
+			state = newSplitState(files)
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		defer signal.Stop(sigCh)
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
 
-	futureWrite, err := s.write(ctx, cancel, chLine, param.Split, param)
+		defer func() {
+			if err := s.svc.writeState(param.StateFile, state); err != nil {
+				retErr = multierror.Append(retErr, fmt.Errorf("writeState: %w", err))
+			}
+		}()
+	}
+
+	parallelWrite := param.parallelWrite()
+	parallelRead := param.parallelRead()
+
+	chLine := make(chan line, parallelWrite)
+
+	var futureWrite Future[int64]
+	var err error
+	if param.rolling() {
+		futureWrite, err = s.writeRolling(ctx, cancel, chLine, param, state)
+	} else {
+		futureWrite, err = s.write(ctx, cancel, chLine, param.Split, param, state)
+	}
 	if err != nil {
 		return fmt.Errorf("write: %w", err)
 	}
 
-	chTarget := make(chan readTarget, param.Parallelism)
+	chTarget := make(chan readTarget, parallelRead)
 
-	futureScan, err := s.scan(ctx, cancel, chTarget, param.Parallelism, param, chLine)
+	futureScan, err := s.scan(ctx, cancel, chTarget, parallelRead, param, chLine, state)
 	if err != nil {
 		return fmt.Errorf("scan: %w", err)
 	}
 
-	futureFile, err := s.file(ctx, cancel, files, param, chTarget)
+	futureFile, err := s.file(ctx, cancel, files, param, chTarget, state)
 	if err != nil {
 		return fmt.Errorf("file: %w", err)
 	}
@@ -134,13 +326,16 @@ func (f *future[T]) Get() (T, error) {
 	return f.result, err
 }
 
-func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, parallelism int, param Param) (_ Future[int64], retErr error) {
+func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, parallelism int, param Param, state *splitState) (_ Future[int64], retErr error) {
 	defer func() {
 		if retErr != nil {
 			cancel()
 		}
 	}()
 
+	pool := newCompressionPool(param.CompressionConcurrency)
+	resume := state != nil && param.Resume
+
 	eg, ctx := errgroup.WithContext(ctx)
 	ret := future[int64]{
 		eg:     eg,
@@ -162,7 +357,7 @@ func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, p
 				}
 			}()
 
-			w, cleanup, err := s.svc.createWriter(fn, param.Compress)
+			w, cleanup, err := s.svc.createWriter(fn, param.Compress, param.CompressLevel, resume)
 			if err != nil {
 				return fmt.Errorf("createWriter: %w", err)
 			}
@@ -170,6 +365,9 @@ func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, p
 
 			lf := []byte("\n")
 			lc := int64(0)
+			if state != nil {
+				lc = state.shardLines(fn)
+			}
 			defer func() {
 				atomic.AddInt64(&ret.result, lc)
 			}()
@@ -177,13 +375,25 @@ func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, p
 				select {
 				case <-ctx.Done():
 					return ctx.Err()
-				case line, ok := <-chIn:
+				case ln, ok := <-chIn:
 					if !ok {
 						return nil
 					}
-					w.Write([]byte(line))
-					w.Write(lf)
+					if err := pool.run(ctx, func() error {
+						w.Write([]byte(ln.text))
+						w.Write(lf)
+						return nil
+					}); err != nil {
+						return err
+					}
 					lc++
+					if state != nil {
+						state.setShardLines(fn, lc)
+						state.setOffset(ln.path, ln.offset)
+						if ln.last {
+							state.markFileDone(ln.path)
+						}
+					}
 				}
 			}
 		})
@@ -192,7 +402,140 @@ func (s *Splitter) write(ctx context.Context, cancel func(), chIn <-chan line, p
 	return &ret, nil
 }
 
-func (s *Splitter) scan(ctx context.Context, cancel func(), chIn <-chan readTarget, parallelism int, param Param, chOut chan<- line) (_ Future[lineCount], retErr error) {
+// shardSuffixWidth is fixed for the whole run so shard numbers always sort
+// lexically in the order they were written. 6 digits comfortably covers any
+// realistic rollover count (split -d defaults to the same width for the
+// same reason); unlike a width derived from the current shard number, it
+// never grows mid-run and so never regresses a prior run's file ordering.
+const shardSuffixWidth = 6
+
+func shardSuffix(shard int) string {
+	return fmt.Sprintf("%0*d", shardSuffixWidth, shard)
+}
+
+// writeRolling routes lines sequentially to a single active writer, rolling
+// over to a new numbered output whenever param.SplitBytes (uncompressed
+// bytes written) or param.SplitLines is crossed. Unlike write, which fans
+// lines out to param.Split concurrent writers, only one shard is open at a
+// time so ordering within a shard is preserved.
+func (s *Splitter) writeRolling(ctx context.Context, cancel func(), chIn <-chan line, param Param, state *splitState) (_ Future[int64], retErr error) {
+	defer func() {
+		if retErr != nil {
+			cancel()
+		}
+	}()
+
+	eg, ctx := errgroup.WithContext(ctx)
+	ret := future[int64]{
+		eg:     eg,
+		result: 0,
+	}
+
+	eg.Go(func() (retErr error) {
+		defer func() {
+			if retErr != nil {
+				cancel()
+			}
+		}()
+
+		_, suffix := getCompressionType(param.Compress)
+		resume := state != nil && param.Resume
+
+		var shard int
+		var shardLines, shardBytes int64
+		if state != nil {
+			shard, shardLines, shardBytes = state.rolling()
+		}
+
+		var w io.Writer
+		cleanup := nop
+
+		rollover := func() error {
+			if err := cleanup(); err != nil {
+				return fmt.Errorf("cleanup: %w", err)
+			}
+			w = nil
+			cleanup = nop
+			shard++
+			shardLines = 0
+			shardBytes = 0
+			if state != nil {
+				state.setRolling(shard, shardLines, shardBytes)
+			}
+			return nil
+		}
+		defer func() {
+			if cerr := cleanup(); cerr != nil && retErr == nil {
+				retErr = fmt.Errorf("cleanup: %w", cerr)
+			}
+		}()
+
+		ensureWriter := func() error {
+			if w != nil {
+				return nil
+			}
+
+			fn := fmt.Sprintf("%s%s%s", param.Prefix, shardSuffix(shard), suffix)
+			dir := path.Dir(fn)
+			if err := s.svc.mkdirAll(dir, os.ModePerm); err != nil {
+				return fmt.Errorf("mkdirAll: %w", err)
+			}
+
+			nw, ncleanup, err := s.svc.createWriter(fn, param.Compress, param.CompressLevel, resume)
+			if err != nil {
+				return fmt.Errorf("createWriter: %w", err)
+			}
+			w = nw
+			cleanup = ncleanup
+			return nil
+		}
+
+		lf := []byte("\n")
+		lc := int64(0)
+		defer func() {
+			atomic.AddInt64(&ret.result, lc)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ln, ok := <-chIn:
+				if !ok {
+					return nil
+				}
+
+				if err := ensureWriter(); err != nil {
+					return err
+				}
+
+				n, _ := w.Write([]byte(ln.text))
+				w.Write(lf)
+				lc++
+				shardLines++
+				shardBytes += int64(n) + int64(len(lf))
+				if state != nil {
+					state.setRolling(shard, shardLines, shardBytes)
+					state.setOffset(ln.path, ln.offset)
+					if ln.last {
+						state.markFileDone(ln.path)
+					}
+				}
+
+				if (param.SplitLines > 0 && shardLines >= param.SplitLines) ||
+					(param.SplitBytes > 0 && shardBytes >= param.SplitBytes) {
+					if err := rollover(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	})
+
+	return &ret, nil
+}
+
+func (s *Splitter) scan(ctx context.Context, cancel func(), chIn <-chan readTarget, parallelism int, param Param, chOut chan<- line, state *splitState) (_ Future[lineCount], retErr error) {
 	defer func() {
 		if retErr != nil {
 			cancel()
@@ -221,22 +564,71 @@ func (s *Splitter) scan(ctx context.Context, cancel func(), chIn <-chan readTarg
 
 					defer r.Close()
 
+					offset := int64(0)
+					if state != nil {
+						offset = state.file(tg.path).Offset
+						if offset > 0 {
+							// readCleanupCloser always has a Seek method, so
+							// ask whether the reader it wraps can really seek.
+							seeker, ok := r.(io.Seeker)
+							if rc, isRC := r.(*readCleanupCloser); isRC {
+								_, ok = rc.Reader.(io.Seeker)
+							}
+							if ok {
+								if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+									return fmt.Errorf("Seek: %w", err)
+								}
+							} else if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+								return fmt.Errorf("resume skip: %w", err)
+							}
+						}
+					}
+
 					lc := int64(0)
 					scanner := bufio.NewScanner(r)
-					for scanner.Scan() {
+
+					// pending holds the most recently scanned line so its
+					// last field can be set once we know, by failing to
+					// scan a successor, that it really is the file's last
+					// line. Checkpointing (setOffset/markFileDone) happens
+					// in the writer stage once each line is durably
+					// written, not here.
+					var pending *line
+					send := func(ln line) error {
 						select {
 						case <-ctx.Done():
 							return ctx.Err()
-						case chOut <- line(scanner.Text()):
-							lc++
-							if param.Verbose && lc%10000 == 0 {
-								fmt.Fprintf(s.stderr, "%s, line=%s\n", tg.path, humanize.Comma(lc))
+						case chOut <- ln:
+							return nil
+						}
+					}
+					for scanner.Scan() {
+						if pending != nil {
+							if err := send(*pending); err != nil {
+								return err
 							}
 						}
+						offset += int64(len(scanner.Bytes())) + 1
+						lc++
+						pending = &line{path: tg.path, text: scanner.Text(), offset: offset}
+						if param.Verbose && lc%10000 == 0 {
+							fmt.Fprintf(s.stderr, "%s, line=%s\n", tg.path, humanize.Comma(lc))
+						}
 					}
 					if err := scanner.Err(); err != nil {
 						return fmt.Errorf("Scan: %w", err)
 					}
+					if pending != nil {
+						pending.last = true
+						if err := send(*pending); err != nil {
+							return err
+						}
+					} else if state != nil {
+						// Nothing to write for this file, so there is
+						// nothing for a writer to confirm: it's done as
+						// soon as scan says so.
+						state.markFileDone(tg.path)
+					}
 					if param.Verbose {
 						fmt.Fprintf(s.stderr, "%s, total=%s\n", tg.path, humanize.Comma(lc))
 					}
@@ -257,7 +649,7 @@ func (s *Splitter) scan(ctx context.Context, cancel func(), chIn <-chan readTarg
 type lineCount int64
 type fileCount int64
 
-func (s *Splitter) file(ctx context.Context, cancel func(), files []string, param Param, chOut chan<- readTarget) (_ Future[fileCount], retErr error) {
+func (s *Splitter) file(ctx context.Context, cancel func(), files []string, param Param, chOut chan<- readTarget, state *splitState) (_ Future[fileCount], retErr error) {
 	defer func() {
 		if retErr != nil {
 			defer cancel()
@@ -276,17 +668,41 @@ func (s *Splitter) file(ctx context.Context, cancel func(), files []string, para
 			}
 		}()
 
-		for _, fn := range files {
+		push := func(path string) error {
+			if state != nil && state.file(path).Done {
+				return nil
+			}
+
 			if param.Verbose {
-				fmt.Fprintf(s.stderr, "%s\n", fn)
+				fmt.Fprintf(s.stderr, "%s\n", path)
 			}
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case chOut <- readTarget{
-				path: fn,
+				path: path,
 			}:
 				atomic.AddInt64((*int64)(&ret.result), 1)
+				return nil
+			}
+		}
+
+		for _, fn := range files {
+			if isZipPath(fn) {
+				entries, err := s.svc.zipEntries(fn)
+				if err != nil {
+					return fmt.Errorf("zipEntries: %w", err)
+				}
+				for _, entry := range entries {
+					if err := push(zipEntryPath(fn, entry)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			if err := push(fn); err != nil {
+				return err
 			}
 		}
 
@@ -296,7 +712,9 @@ func (s *Splitter) file(ctx context.Context, cancel func(), files []string, para
 	return &ret, nil
 }
 
-type serviceImpl struct{}
+type serviceImpl struct {
+	fs afero.Fs
+}
 
 var _ io.ReadCloser = (*readCleanupCloser)(nil)
 
@@ -309,6 +727,18 @@ func (c *readCleanupCloser) Close() error {
 	return c.cleanups.do()
 }
 
+// Seek delegates to the wrapped Reader when it supports seeking.
+// io.Reader is embedded as an interface-typed field, so Go never
+// promotes Seek from it automatically even when the concrete value
+// underneath (e.g. seekableReader) implements io.Seeker.
+func (c *readCleanupCloser) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := c.Reader.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("readCleanupCloser: underlying reader is not seekable")
+	}
+	return seeker.Seek(offset, whence)
+}
+
 func (s *serviceImpl) createReader(fn string) (_ io.ReadCloser, retErr error) {
 	cleanups := &cleanups{}
 	defer func() {
@@ -317,13 +747,42 @@ func (s *serviceImpl) createReader(fn string) (_ io.ReadCloser, retErr error) {
 		}
 	}()
 
-	fp, err := os.Open(fn)
+	entryName := fn
+	if archive, entry, ok := splitZipEntryPath(fn); ok {
+		zr, fp, err := s.openZipReader(archive)
+		if err != nil {
+			return nil, fmt.Errorf("openZipReader: %w", err)
+		}
+		cleanups.add(func() error { return fp.Close() })
+
+		zf, err := findZipEntry(zr, entry)
+		if err != nil {
+			return nil, fmt.Errorf("findZipEntry: %w", err)
+		}
+
+		zer, err := newZipEntryReader(zf)
+		if err != nil {
+			return nil, fmt.Errorf("newZipEntryReader: %w", err)
+		}
+		cleanups.add(func() error { return zer.Close() })
+
+		entryName = entry
+		r, err := decorateReader(entryName, zer)
+		if err != nil {
+			return nil, fmt.Errorf("decorateReader: %w", err)
+		}
+		cleanups.add(func() error { return r.Close() })
+
+		return &readCleanupCloser{Reader: r, cleanups: cleanups}, nil
+	}
+
+	fp, err := s.fs.Open(fn)
 	if err != nil {
-		return nil, fmt.Errorf("os.Open: %w", err)
+		return nil, fmt.Errorf("fs.Open: %w", err)
 	}
 	cleanups.add(func() error { return fp.Close() })
 
-	r, err := decorateReader(fn, fp)
+	r, err := decorateReader(entryName, fp)
 	if err != nil {
 		return nil, fmt.Errorf("decorateReader: %w", err)
 	}
@@ -337,10 +796,10 @@ func (s *serviceImpl) createReader(fn string) (_ io.ReadCloser, retErr error) {
 }
 
 func (s *serviceImpl) mkdirAll(path string, perm os.FileMode) error {
-	return os.MkdirAll(path, perm)
+	return s.fs.MkdirAll(path, perm)
 }
 
-func (s *serviceImpl) createWriter(fn string, compress string) (_ io.Writer, _ cleanupFunc, retErr error) {
+func (s *serviceImpl) createWriter(fn string, compress string, level int, resume bool) (_ io.Writer, _ cleanupFunc, retErr error) {
 	cleanups := &cleanups{}
 	defer func() {
 		if retErr != nil {
@@ -348,13 +807,19 @@ func (s *serviceImpl) createWriter(fn string, compress string) (_ io.Writer, _ c
 		}
 	}()
 
-	fp, err := os.Create(fn)
+	var fp afero.File
+	var err error
+	if resume {
+		fp, err = s.fs.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	} else {
+		fp, err = s.fs.Create(fn)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 	cleanups.add(func() error { return fp.Close() })
 
-	w, cleanup, err := decorateWriter(compress, fp)
+	w, cleanup, err := decorateWriter(compress, level, fp)
 	if err != nil {
 		return nil, nil, fmt.Errorf("decorateWriter: %w", err)
 	}
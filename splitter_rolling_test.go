@@ -0,0 +1,94 @@
+package split
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRollingLines(t *testing.T) {
+	param := Param{
+		SplitLines:  2,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+	}
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
+line2
+line3
+line4
+`), os.ModePerm))
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	shard0, err := afero.ReadFile(fs, "out/file-000000")
+	assert.Nil(t, err)
+	assert.Equal(t, "line1\nline2\n", string(shard0))
+
+	shard1, err := afero.ReadFile(fs, "out/file-000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "line3\nline4\n", string(shard1))
+
+	// Line count divides evenly into SplitLines, so there must be no
+	// trailing empty shard.
+	_, err = fs.Open("out/file-000002")
+	assert.NotNil(t, err)
+}
+
+func TestSplitRollingLinesUnevenFinalShard(t *testing.T) {
+	param := Param{
+		SplitLines:  2,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+	}
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
+line2
+line3
+`), os.ModePerm))
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	shard1, err := afero.ReadFile(fs, "out/file-000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "line3\n", string(shard1))
+
+	_, err = fs.Open("out/file-000002")
+	assert.NotNil(t, err)
+}
+
+func TestSplitRollingBytesMidLine(t *testing.T) {
+	param := Param{
+		// "line1\n" is 6 bytes, so a threshold of 8 crosses mid-second-line.
+		SplitBytes:  8,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+	}
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
+line2
+line3
+`), os.ModePerm))
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	shard0, err := afero.ReadFile(fs, "out/file-000000")
+	assert.Nil(t, err)
+	assert.Equal(t, "line1\nline2\n", string(shard0))
+
+	shard1, err := afero.ReadFile(fs, "out/file-000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "line3\n", string(shard1))
+}
@@ -4,30 +4,19 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
-type serviceMock struct {
-	mockCreateWriter func(fn string, compress string) (io.Writer, cleanupFunc, error)
-	mockCreateReader func(fn string) (io.ReadCloser, error)
-	mockMkdirAll     func(path string, perm os.FileMode) error
-}
-
-func (m *serviceMock) createWriter(fn string, compress string) (io.Writer, cleanupFunc, error) {
-	return m.mockCreateWriter(fn, compress)
-}
-func (m *serviceMock) createReader(fn string) (io.ReadCloser, error) {
-	return m.mockCreateReader(fn)
-}
-func (m *serviceMock) mkdirAll(path string, perm os.FileMode) error {
-	return m.mockMkdirAll(path, perm)
-}
-
 func TestSplit1(t *testing.T) {
 	param := Param{
 		Verbose:     false,
@@ -37,37 +26,24 @@ func TestSplit1(t *testing.T) {
 		Compress:    "",
 	}
 
-	s := NewSplitter()
-	actual := bytes.NewBuffer([]byte{})
-	mock := &serviceMock{
-		mockMkdirAll: func(path string, perm os.FileMode) error {
-			assert.Equal(t, "out", path)
-			return nil
-		},
-		mockCreateReader: func(fn string) (io.ReadCloser, error) {
-			assert.Equal(t, "in/file0", fn)
-			return io.NopCloser(strings.NewReader(`line1
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
 line2
 line3
-`)), nil
-		},
-		mockCreateWriter: func(fn string, compress string) (io.Writer, cleanupFunc, error) {
-			assert.Equal(t, "out/file-000", fn)
-			assert.Equal(t, "", compress)
-			return actual, nop, nil
-		},
-	}
+`), os.ModePerm))
 
-	s.svc = mock
+	s := NewSplitterWithFs(fs)
 
 	s.Do(context.Background(), []string{
 		"in/file0",
 	}, param)
 
+	actual, err := afero.ReadFile(fs, "out/file-000")
+	assert.Nil(t, err)
 	assert.Equal(t, `line1
 line2
 line3
-`, actual.String())
+`, string(actual))
 }
 
 func TestSplit2(t *testing.T) {
@@ -79,46 +55,72 @@ func TestSplit2(t *testing.T) {
 		Compress:    "gzip",
 	}
 
-	s := NewSplitter()
-	stderr := bytes.NewBuffer([]byte{})
-	s.stderr = stderr
-
-	actual := bytes.NewBuffer([]byte{})
-	mock := &serviceMock{
-		mockMkdirAll: func(path string, perm os.FileMode) error {
-			assert.Equal(t, "out", path)
-			return nil
-		},
-		mockCreateReader: func(fn string) (io.ReadCloser, error) {
-			assert.Equal(t, "in/file0", fn)
-			return io.NopCloser(strings.NewReader(`line1
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
 line2
 line3
-`)), nil
-		},
-		mockCreateWriter: func(fn string, compress string) (io.Writer, cleanupFunc, error) {
-			assert.Equal(t, "out/file-000.gz", fn)
-			assert.Equal(t, "gzip", compress)
-			return actual, nop, nil
-		},
-	}
+`), os.ModePerm))
 
-	s.svc = mock
+	s := NewSplitterWithFs(fs)
+	stderr := bytes.NewBuffer([]byte{})
+	s.stderr = stderr
 
 	s.Do(context.Background(), []string{
 		"in/file0",
 	}, param)
 
+	fp, err := fs.Open("out/file-000.gz")
+	assert.Nil(t, err)
+	defer fp.Close()
+
+	gzr, err := gzip.NewReader(fp)
+	assert.Nil(t, err)
+	defer gzr.Close()
+
+	actual, err := io.ReadAll(gzr)
+	assert.Nil(t, err)
 	assert.Equal(t, `line1
 line2
 line3
-`, actual.String())
+`, string(actual))
 
 	assert.Equal(t, `in/file0
 in/file0, total=3
 `, stderr.String())
 }
 
+func TestSplitCountIndependentOfParallelism(t *testing.T) {
+	param := Param{
+		Split:       4,
+		Parallelism: 2,
+		Prefix:      "out/file-",
+	}
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(`line1
+line2
+line3
+line4
+`), os.ModePerm))
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	var lines []string
+	for i := 0; i < param.Split; i++ {
+		fn := fmt.Sprintf("out/file-%03d", i)
+		content, err := afero.ReadFile(fs, fn)
+		assert.Nil(t, err, "shard %s must exist even though -parallelism is smaller than -split", fn)
+		if trimmed := strings.TrimSuffix(string(content), "\n"); trimmed != "" {
+			lines = append(lines, strings.Split(trimmed, "\n")...)
+		}
+	}
+
+	sort.Strings(lines)
+	assert.Equal(t, []string{"line1", "line2", "line3", "line4"}, lines)
+}
+
 func TestDecorateReaderNoCompression(t *testing.T) {
 	r, err := os.Open("/dev/stdin")
 	assert.Nil(t, err)
@@ -129,51 +131,74 @@ func TestDecorateReaderNoCompression(t *testing.T) {
 	defer reader.Close()
 }
 
-func TestDecorateReaderGzip(t *testing.T) {
+func TestDecorateWriterNone(t *testing.T) {
 	buf := bytes.NewBuffer([]byte{})
-	w := gzip.NewWriter(buf)
-	defer w.Close()
-	w.Write([]byte("aaa"))
-	w.Close()
-
-	r := bytes.NewReader(buf.Bytes())
-
-	reader, err := decorateReader("path/to/some.tsv.gz", r)
+	w, cleanup, err := decorateWriter("none", 0, buf)
 	assert.Nil(t, err)
-	defer reader.Close()
-
-	_, ok := reader.(*gzip.Reader)
-	assert.True(t, ok)
-
-	content, err := io.ReadAll(reader)
-	assert.Nil(t, err)
-	assert.Equal(t, "aaa", string(content))
+	defer cleanup()
 
+	assert.True(t, buf == w)
 }
 
-func TestDecorateWriterNone(t *testing.T) {
+func TestDecorateWriterUnknown(t *testing.T) {
 	buf := bytes.NewBuffer([]byte{})
-	w, cleanup, err := decorateWriter("none", buf)
-	assert.Nil(t, err)
-	defer cleanup()
+	w, cleanup, err := decorateWriter("lzo", 0, buf)
+	assert.NotNil(t, err)
+	assert.Nil(t, w)
+	assert.Nil(t, cleanup)
+}
 
-	assert.True(t, buf == w)
+func TestDecorateRoundTrip(t *testing.T) {
+	cases := []struct {
+		name       string
+		compress   string
+		extension  string
+		writerType any
+	}{
+		{"gzip", "gzip", ".gz", (*gzip.Writer)(nil)},
+		{"zstd", "zstd", ".zst", (*zstd.Encoder)(nil)},
+		{"brotli", "brotli", ".br", (*brotli.Writer)(nil)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+			w, cleanup, err := decorateWriter(tc.compress, 0, buf)
+			assert.Nil(t, err)
+			assert.IsType(t, tc.writerType, w)
+
+			_, err = w.Write([]byte("hello, world\n"))
+			assert.Nil(t, err)
+			assert.Nil(t, cleanup())
+
+			reader, err := decorateReader("in"+tc.extension, bytes.NewReader(buf.Bytes()))
+			assert.Nil(t, err)
+			defer reader.Close()
+
+			content, err := io.ReadAll(reader)
+			assert.Nil(t, err)
+			assert.Equal(t, "hello, world\n", string(content))
+		})
+	}
 }
 
-func TestDecorateWriterGzip(t *testing.T) {
+func TestDecorateReaderMisnamedExtension(t *testing.T) {
+	// gzip/bzip2/zstd are detected from their magic bytes, so a wrong
+	// extension doesn't prevent decompression.
 	buf := bytes.NewBuffer([]byte{})
-	w, cleanup, err := decorateWriter("gzip", buf)
+	w := gzip.NewWriter(buf)
+	_, err := w.Write([]byte("aaa"))
 	assert.Nil(t, err)
-	defer cleanup()
+	assert.Nil(t, w.Close())
 
-	_, ok := w.(*gzip.Writer)
+	reader, err := decorateReader("path/to/some.tsv", bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	defer reader.Close()
+
+	_, ok := reader.(*gzip.Reader)
 	assert.True(t, ok)
-}
 
-func TestDecorateWriterUnknown(t *testing.T) {
-	buf := bytes.NewBuffer([]byte{})
-	w, cleanup, err := decorateWriter("lzo", buf)
-	assert.NotNil(t, err)
-	assert.Nil(t, w)
-	assert.Nil(t, cleanup)
+	content, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "aaa", string(content))
 }
@@ -0,0 +1,185 @@
+package split
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fileState tracks how much of a single input file (or zip entry, addressed
+// by its virtual path) has been written out: Offset is the input position
+// just past the last line a writer committed, where scan resumes.
+type fileState struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Done   bool   `json:"done"`
+}
+
+// shardState tracks how many lines have been committed to a single output
+// shard, so writers can reopen it in append mode on resume.
+type shardState struct {
+	Lines int64 `json:"lines"`
+}
+
+// splitState is the checkpoint persisted to Param.StateFile. It is shared
+// by the file/scan/write stages of a single Do call, so all access goes
+// through its mutex-guarded methods.
+type splitState struct {
+	mu sync.Mutex
+
+	Files  []*fileState           `json:"files"`
+	Shards map[string]*shardState `json:"shards"`
+
+	// RollingShard/RollingLines/RollingBytes checkpoint the single active
+	// writer used by writeRolling, since that stage has only one shard open
+	// at a time rather than one per Param.Split writer.
+	RollingShard int   `json:"rollingShard"`
+	RollingLines int64 `json:"rollingLines"`
+	RollingBytes int64 `json:"rollingBytes"`
+}
+
+func newSplitState(files []string) *splitState {
+	st := &splitState{Shards: map[string]*shardState{}}
+	for _, fn := range files {
+		st.Files = append(st.Files, &fileState{Path: fn})
+	}
+	return st
+}
+
+func (st *splitState) file(path string) *fileState {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, f := range st.Files {
+		if f.Path == path {
+			return f
+		}
+	}
+	f := &fileState{Path: path}
+	st.Files = append(st.Files, f)
+	return f
+}
+
+func (st *splitState) setOffset(path string, offset int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, f := range st.Files {
+		if f.Path == path {
+			f.Offset = offset
+			return
+		}
+	}
+	st.Files = append(st.Files, &fileState{Path: path, Offset: offset})
+}
+
+func (st *splitState) markFileDone(path string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, f := range st.Files {
+		if f.Path == path {
+			f.Done = true
+			return
+		}
+	}
+	st.Files = append(st.Files, &fileState{Path: path, Done: true})
+}
+
+func (st *splitState) shardLines(fn string) int64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if sh, ok := st.Shards[fn]; ok {
+		return sh.Lines
+	}
+	return 0
+}
+
+func (st *splitState) setShardLines(fn string, lines int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.Shards == nil {
+		st.Shards = map[string]*shardState{}
+	}
+	sh, ok := st.Shards[fn]
+	if !ok {
+		sh = &shardState{}
+		st.Shards[fn] = sh
+	}
+	sh.Lines = lines
+}
+
+func (st *splitState) setRolling(shard int, lines int64, bytes int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.RollingShard = shard
+	st.RollingLines = lines
+	st.RollingBytes = bytes
+}
+
+func (st *splitState) rolling() (shard int, lines int64, bytes int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.RollingShard, st.RollingLines, st.RollingBytes
+}
+
+// readState loads fn via the same afero.Fs used for inputs/outputs. A
+// missing file is not an error: it means this is the first run, so a fresh
+// splitState should be created by the caller.
+func (s *serviceImpl) readState(fn string) (*splitState, error) {
+	fp, err := s.fs.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fs.Open: %w", err)
+	}
+	defer fp.Close()
+
+	var st splitState
+	if err := json.NewDecoder(fp).Decode(&st); err != nil {
+		return nil, fmt.Errorf("Decode: %w", err)
+	}
+	if st.Shards == nil {
+		st.Shards = map[string]*shardState{}
+	}
+	return &st, nil
+}
+
+// writeState persists st to fn atomically, via a temp file plus rename, so
+// a crash mid-write never leaves a corrupt checkpoint behind.
+func (s *serviceImpl) writeState(fn string, st *splitState) (retErr error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	tmp := fn + ".tmp"
+	fp, err := s.fs.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("fs.Create: %w", err)
+	}
+	defer func() {
+		if retErr != nil {
+			_ = s.fs.Remove(tmp)
+		}
+	}()
+
+	enc := json.NewEncoder(fp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(st); err != nil {
+		fp.Close()
+		return fmt.Errorf("Encode: %w", err)
+	}
+	if err := fp.Close(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+
+	if err := s.fs.Rename(tmp, fn); err != nil {
+		return fmt.Errorf("fs.Rename: %w", err)
+	}
+	return nil
+}
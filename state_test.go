@@ -0,0 +1,80 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeFromHandCraftedCheckpoint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte("line1\nline2\nline3\n"), os.ModePerm))
+	// Simulate a prior run that had already written "line1\n" to the shard
+	// and recorded that it had consumed the matching 6 bytes of input.
+	assert.Nil(t, afero.WriteFile(fs, "out/file-000", []byte("line1\n"), os.ModePerm))
+
+	svc := &serviceImpl{fs: fs}
+	assert.Nil(t, svc.writeState("state.json", &splitState{
+		Files: []*fileState{
+			{Path: "in/file0", Offset: 6},
+		},
+		Shards: map[string]*shardState{
+			"out/file-000": {Lines: 1},
+		},
+	}))
+
+	param := Param{
+		Split:       1,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+		StateFile:   "state.json",
+		Resume:      true,
+	}
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	actual, err := afero.ReadFile(fs, "out/file-000")
+	assert.Nil(t, err)
+	assert.Equal(t, "line1\nline2\nline3\n", string(actual))
+}
+
+func TestResumeAfterCancellation(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, "line")
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	fs := afero.NewMemMapFs()
+	assert.Nil(t, afero.WriteFile(fs, "in/file0", []byte(content), os.ModePerm))
+
+	param := Param{
+		Split:       1,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+		StateFile:   "state.json",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := NewSplitterWithFs(fs)
+	err := s.Do(ctx, []string{"in/file0"}, param)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	param.Resume = true
+	s2 := NewSplitterWithFs(fs)
+	err = s2.Do(context.Background(), []string{"in/file0"}, param)
+	assert.Nil(t, err)
+
+	actual, err := afero.ReadFile(fs, "out/file-000")
+	assert.Nil(t, err)
+	assert.Equal(t, content, string(actual))
+}
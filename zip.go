@@ -0,0 +1,109 @@
+package split
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const zipEntrySep = "!"
+
+// isZipPath reports whether fn refers to a zip archive, i.e. everything up
+// to a ".zip" suffix, as opposed to a virtual "archive.zip!entry" path
+// pointing at one of its entries.
+func isZipPath(fn string) bool {
+	return strings.HasSuffix(fn, ".zip")
+}
+
+// zipEntryPath formats the virtual path used to address a single entry of a
+// zip archive, e.g. "foo.zip!bar/log.gz".
+func zipEntryPath(archive string, entry string) string {
+	return archive + zipEntrySep + entry
+}
+
+// splitZipEntryPath splits a virtual path produced by zipEntryPath back into
+// the archive path and the entry name. ok is false when fn does not address
+// a zip entry.
+func splitZipEntryPath(fn string) (archive string, entry string, ok bool) {
+	archive, entry, found := strings.Cut(fn, zipEntrySep)
+	if !found || !isZipPath(archive) {
+		return "", "", false
+	}
+	return archive, entry, true
+}
+
+// zipEntryReader adapts the io.ReadCloser returned by (*zip.File).Open into
+// the readCleanupCloser chain, so that closing the returned reader releases
+// both the decompressed entry stream and, via the caller's cleanups, the
+// archive it came from.
+type zipEntryReader struct {
+	zf *zip.File
+	io.ReadCloser
+}
+
+func newZipEntryReader(zf *zip.File) (*zipEntryReader, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("zf.Open: %w", err)
+	}
+	return &zipEntryReader{zf: zf, ReadCloser: rc}, nil
+}
+
+// openZipReader opens fn as a zip archive and returns its directory along
+// with the underlying afero.File, which the caller is responsible for
+// closing once done with the *zip.Reader.
+func (s *serviceImpl) openZipReader(fn string) (_ *zip.Reader, _ afero.File, retErr error) {
+	fp, err := s.fs.Open(fn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fs.Open: %w", err)
+	}
+	defer func() {
+		if retErr != nil {
+			fp.Close()
+		}
+	}()
+
+	fi, err := fp.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Stat: %w", err)
+	}
+
+	zr, err := zip.NewReader(fp, fi.Size())
+	if err != nil {
+		return nil, nil, fmt.Errorf("zip.NewReader: %w", err)
+	}
+
+	return zr, fp, nil
+}
+
+// zipEntries returns the names of the regular-file entries of the zip
+// archive at fn, in the order they appear in the archive's central
+// directory.
+func (s *serviceImpl) zipEntries(fn string) ([]string, error) {
+	zr, fp, err := s.openZipReader(fn)
+	if err != nil {
+		return nil, fmt.Errorf("openZipReader: %w", err)
+	}
+	defer fp.Close()
+
+	names := make([]string, 0, len(zr.File))
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		names = append(names, zf.Name)
+	}
+	return names, nil
+}
+
+func findZipEntry(zr *zip.Reader, name string) (*zip.File, error) {
+	for _, zf := range zr.File {
+		if zf.Name == name {
+			return zf, nil
+		}
+	}
+	return nil, fmt.Errorf("no such entry in zip: %s", name)
+}
@@ -0,0 +1,76 @@
+package split
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestZip(t *testing.T, fs afero.Fs, fn string, entries map[string][]byte) {
+	t.Helper()
+
+	buf := bytes.NewBuffer([]byte{})
+	zw := zip.NewWriter(buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write(content)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+
+	assert.Nil(t, afero.WriteFile(fs, fn, buf.Bytes(), os.ModePerm))
+}
+
+func TestSplitZip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	gzBuf := bytes.NewBuffer([]byte{})
+	gzw := gzip.NewWriter(gzBuf)
+	_, err := gzw.Write([]byte("line2\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, gzw.Close())
+
+	writeTestZip(t, fs, "in/logs.zip", map[string][]byte{
+		"a/line1.txt": []byte("line1\n"),
+		"b/line2.gz":  gzBuf.Bytes(),
+	})
+
+	param := Param{
+		Split:       1,
+		Parallelism: 1,
+		Prefix:      "out/file-",
+	}
+
+	s := NewSplitterWithFs(fs)
+	err = s.Do(context.Background(), []string{"in/logs.zip"}, param)
+	assert.Nil(t, err)
+
+	actual, err := afero.ReadFile(fs, "out/file-000")
+	assert.Nil(t, err)
+
+	lines := map[string]bool{}
+	for _, l := range bytes.Split(bytes.TrimRight(actual, "\n"), []byte("\n")) {
+		lines[string(l)] = true
+	}
+	assert.Equal(t, map[string]bool{"line1": true, "line2": true}, lines)
+}
+
+func TestZipEntryPathRoundTrip(t *testing.T) {
+	p := zipEntryPath("foo.zip", "bar/log.gz")
+	assert.Equal(t, "foo.zip!bar/log.gz", p)
+
+	archive, entry, ok := splitZipEntryPath(p)
+	assert.True(t, ok)
+	assert.Equal(t, "foo.zip", archive)
+	assert.Equal(t, "bar/log.gz", entry)
+
+	_, _, ok = splitZipEntryPath("plain/file.txt")
+	assert.False(t, ok)
+}